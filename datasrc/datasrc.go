@@ -0,0 +1,150 @@
+// Package datasrc defines a pluggable data source abstraction so recon
+// backends other than fofa.so (Shodan, Censys, ZoomEye, crt.sh, ...) can be
+// registered and queried alongside it through the same DSL-shaped query.
+//
+// Sources can be added either by implementing DataSource in Go and calling
+// Register directly, the way fofa.Fofa does, or by dropping a Lua script
+// into a directory and calling datasrc/script.LoadDir, which registers the
+// DataSource each script declares without anyone needing to recompile.
+package datasrc
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Result is a normalized hit, common to every data source.
+type Result struct {
+	Domain  string
+	Host    string
+	IP      string
+	Port    string
+	Title   string
+	Country string
+	City    string
+	// Source is the name the data source was registered under.
+	Source string
+}
+
+// Results is a set of normalized hits.
+type Results []Result
+
+// DataSource is implemented by anything that can answer a DSL query and
+// return normalized results, be it fofa.Fofa or a third-party backend.
+type DataSource interface {
+	// Name identifies the data source, e.g. "fofa" or "shodan".
+	Name() string
+	// Query runs dsl against the data source and returns up to one page
+	// of normalized results. fields restricts which fields are populated,
+	// matching the semantics of fofa.Fofa.QueryAsArray; a nil/empty
+	// fields selects the data source's default set.
+	Query(ctx context.Context, dsl string, page uint, fields []string) (Results, error)
+}
+
+var errNoSources = errors.New("datasrc: no data sources registered")
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]DataSource)
+)
+
+// Register adds src to the set of data sources queried by MultiQuery. A
+// second call with the same name replaces the previously registered source.
+func Register(name string, src DataSource) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[name] = src
+}
+
+// Get returns the data source registered under name, if any.
+func Get(name string) (DataSource, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	src, ok := registry[name]
+	return src, ok
+}
+
+// Sources returns the names of every currently registered data source.
+func Sources() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	return names
+}
+
+// MultiQuery fans dsl out across every registered data source, running up
+// to concurrency queries at a time, and returns the merged results with
+// duplicate hosts removed. concurrency <= 0 means unbounded. If every
+// source fails the first error encountered is returned; a partial failure
+// is reported through err while still returning whatever results did come
+// back.
+func MultiQuery(ctx context.Context, dsl string, page uint, fields []string, concurrency int) (Results, error) {
+	mu.RLock()
+	srcs := make([]DataSource, 0, len(registry))
+	for _, src := range registry {
+		srcs = append(srcs, src)
+	}
+	mu.RUnlock()
+
+	if len(srcs) == 0 {
+		return nil, errNoSources
+	}
+	if concurrency <= 0 {
+		concurrency = len(srcs)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, concurrency)
+		resMu    sync.Mutex
+		merged   Results
+		firstErr error
+		okCount  int
+	)
+	for _, src := range srcs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(src DataSource) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			res, err := src.Query(ctx, dsl, page, fields)
+			resMu.Lock()
+			defer resMu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			okCount++
+			merged = append(merged, res...)
+		}(src)
+	}
+	wg.Wait()
+
+	if okCount == 0 {
+		return nil, firstErr
+	}
+	return dedupe(merged), firstErr
+}
+
+// dedupe removes results that share the same host, ip and port, keeping
+// the first occurrence.
+func dedupe(results Results) Results {
+	seen := make(map[[3]string]struct{}, len(results))
+	out := make(Results, 0, len(results))
+	for _, r := range results {
+		key := [3]string{r.Host, r.IP, r.Port}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, r)
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package datasrc
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeSource is a minimal DataSource for exercising Register/Get/Sources
+// and MultiQuery without depending on fofa or a real backend.
+type fakeSource struct {
+	name    string
+	results Results
+	err     error
+}
+
+func (f *fakeSource) Name() string { return f.name }
+
+func (f *fakeSource) Query(ctx context.Context, dsl string, page uint, fields []string) (Results, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.results, nil
+}
+
+func resetRegistry(t *testing.T) {
+	t.Helper()
+	mu.Lock()
+	registry = make(map[string]DataSource)
+	mu.Unlock()
+}
+
+func TestRegisterOverwritesByName(t *testing.T) {
+	resetRegistry(t)
+
+	first := &fakeSource{name: "shodan"}
+	second := &fakeSource{name: "shodan"}
+	Register("shodan", first)
+	Register("shodan", second)
+
+	got, ok := Get("shodan")
+	if !ok {
+		t.Fatal("expected shodan to be registered")
+	}
+	if got != DataSource(second) {
+		t.Fatal("expected the second Register call to replace the first")
+	}
+}
+
+func TestGetUnknown(t *testing.T) {
+	resetRegistry(t)
+
+	if _, ok := Get("missing"); ok {
+		t.Fatal("expected Get of an unregistered name to report false")
+	}
+}
+
+func TestSourcesListsRegisteredNames(t *testing.T) {
+	resetRegistry(t)
+
+	Register("fofa", &fakeSource{name: "fofa"})
+	Register("shodan", &fakeSource{name: "shodan"})
+
+	names := Sources()
+	if len(names) != 2 {
+		t.Fatalf("got %d names, want 2: %v", len(names), names)
+	}
+	seen := map[string]bool{names[0]: true, names[1]: true}
+	if !seen["fofa"] || !seen["shodan"] {
+		t.Fatalf("unexpected names: %v", names)
+	}
+}
+
+func TestMultiQueryNoSourcesRegistered(t *testing.T) {
+	resetRegistry(t)
+
+	if _, err := MultiQuery(context.Background(), `domain="nosec.org"`, 1, nil, 0); err != errNoSources {
+		t.Fatalf("got err %v, want errNoSources", err)
+	}
+}
+
+func TestMultiQueryMergesAndDedupesAcrossSources(t *testing.T) {
+	resetRegistry(t)
+
+	Register("fofa", &fakeSource{name: "fofa", results: Results{
+		{Host: "a.com", IP: "1.1.1.1", Port: "80"},
+		{Host: "b.com", IP: "2.2.2.2", Port: "80"},
+	}})
+	Register("shodan", &fakeSource{name: "shodan", results: Results{
+		// same host/ip/port as fofa's first result: must be deduped away
+		{Host: "a.com", IP: "1.1.1.1", Port: "80"},
+		{Host: "c.com", IP: "3.3.3.3", Port: "443"},
+	}})
+
+	got, err := MultiQuery(context.Background(), `domain="nosec.org"`, 1, nil, 0)
+	if err != nil {
+		t.Fatalf("MultiQuery: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d results, want 3 after dedupe: %+v", len(got), got)
+	}
+}
+
+func TestMultiQueryAllSourcesFail(t *testing.T) {
+	resetRegistry(t)
+
+	wantErr := errors.New("boom")
+	Register("fofa", &fakeSource{name: "fofa", err: wantErr})
+
+	got, err := MultiQuery(context.Background(), `domain="nosec.org"`, 1, nil, 0)
+	if got != nil {
+		t.Fatalf("got results %+v, want nil", got)
+	}
+	if err != wantErr {
+		t.Fatalf("got err %v, want %v", err, wantErr)
+	}
+}
+
+func TestMultiQueryPartialFailureReturnsResultsAndError(t *testing.T) {
+	resetRegistry(t)
+
+	wantErr := errors.New("shodan unreachable")
+	Register("fofa", &fakeSource{name: "fofa", results: Results{{Host: "a.com", IP: "1.1.1.1", Port: "80"}}})
+	Register("shodan", &fakeSource{name: "shodan", err: wantErr})
+
+	got, err := MultiQuery(context.Background(), `domain="nosec.org"`, 1, nil, 0)
+	if len(got) != 1 || got[0].Host != "a.com" {
+		t.Fatalf("got %+v, want the one successful source's result", got)
+	}
+	if err != wantErr {
+		t.Fatalf("got err %v, want the failing source's error reported alongside the partial results", err)
+	}
+}
+
+func TestDedupeKeepsFirstOccurrence(t *testing.T) {
+	results := Results{
+		{Host: "a.com", IP: "1.1.1.1", Port: "80", Title: "first"},
+		{Host: "a.com", IP: "1.1.1.1", Port: "80", Title: "duplicate"},
+		{Host: "a.com", IP: "1.1.1.1", Port: "443", Title: "different port, not a duplicate"},
+	}
+
+	got := dedupe(results)
+	if len(got) != 2 {
+		t.Fatalf("got %d results, want 2: %+v", len(got), got)
+	}
+	if got[0].Title != "first" {
+		t.Fatalf("expected the first occurrence to be kept, got %+v", got[0])
+	}
+}
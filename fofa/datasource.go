@@ -0,0 +1,41 @@
+package fofa
+
+import (
+	"context"
+
+	"github.com/lfany/fofa-go/datasrc"
+)
+
+// Name identifies this client as the "fofa" data source.
+func (ff *Fofa) Name() string {
+	return "fofa"
+}
+
+// Query implements datasrc.DataSource so a Fofa client can be registered
+// and queried alongside other recon backends via datasrc.MultiQuery.
+func (ff *Fofa) Query(ctx context.Context, dsl string, page uint, fields []string) (datasrc.Results, error) {
+	fofaFields := make([]Field, len(fields))
+	for i, f := range fields {
+		fofaFields[i] = Field(f)
+	}
+
+	results, err := ff.QueryAsArrayContext(ctx, page, dsl, fofaFields...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(datasrc.Results, len(results))
+	for i, r := range results {
+		out[i] = datasrc.Result{
+			Domain:  r.Domain,
+			Host:    r.Host,
+			IP:      r.IP,
+			Port:    r.Port,
+			Title:   r.Title,
+			Country: r.Country,
+			City:    r.City,
+			Source:  ff.Name(),
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,95 @@
+package fofa
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errCanceled is returned by QueryAsJSONContext/QueryAsArrayContext once
+// the client's deadline (see SetDeadline) has fired, for that call and
+// every call made before the deadline is reset.
+var errCanceled = errors.New("fofa: client deadline exceeded")
+
+// SetDeadline arms a per-client default timeout: once d elapses, the
+// client is marked expired and any request already in flight, or started
+// before the deadline next changes, is cancelled and fails fast with
+// errCanceled. Calling SetDeadline again before that happens — renewing
+// or clearing the deadline, the same as calling it the first time —
+// only supersedes the pending expiry; it does not cancel requests that
+// are already in flight under the old deadline. A zero or negative d
+// clears any previously set deadline.
+func (ff *Fofa) SetDeadline(d time.Duration) {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	// Renewing before expiry keeps the same deadline context, so requests
+	// already in flight are still cancelled when the renewed deadline fires.
+	if d > 0 && ff.timer != nil && ff.timer.Stop() {
+		ff.timer.Reset(d)
+		return
+	}
+	if ff.timer != nil {
+		ff.timer.Stop()
+	}
+	ff.expired = false
+	ff.generation++
+	generation := ff.generation
+
+	if d <= 0 {
+		ff.deadlineCtx = nil
+		ff.timer = nil
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ff.deadlineCtx = ctx
+	ff.timer = time.AfterFunc(d, func() {
+		ff.mu.Lock()
+		// Guard against a timer that already fired (Stop returning too
+		// late to prevent it) from resurrecting expired after a
+		// subsequent SetDeadline reset or renewed it.
+		if ff.generation == generation {
+			ff.expired = true
+		}
+		ff.mu.Unlock()
+		cancel()
+	})
+}
+
+// withDeadline derives a context from ctx that is also cancelled when the
+// client's deadline fires.
+func (ff *Fofa) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	ff.mu.Lock()
+	deadlineCtx := ff.deadlineCtx
+	ff.mu.Unlock()
+
+	if deadlineCtx == nil {
+		return ctx, func() {}
+	}
+
+	merged, cancel := context.WithCancel(ctx)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-deadlineCtx.Done():
+			cancel()
+		case <-stop:
+		}
+	}()
+	return merged, func() {
+		close(stop)
+		cancel()
+	}
+}
+
+// expiredErr reports whether the client's deadline has already fired, in
+// which case every call fails fast with errCanceled until it is reset.
+func (ff *Fofa) expiredErr() error {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	if ff.expired {
+		return errCanceled
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package fofa
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQueryAsJSONContextCanceled(t *testing.T) {
+	ff := NewFofaClient([]byte("email"), []byte("key"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ff.QueryAsJSONContext(ctx, 1, []byte(`domain="nosec.org"`)); err == nil {
+		t.Fatal("expected error for an already-canceled context")
+	}
+}
+
+func TestSetDeadlineFailsFast(t *testing.T) {
+	ff := NewFofaClient([]byte("email"), []byte("key"))
+
+	ff.SetDeadline(10 * time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	_, err := ff.QueryAsJSONContext(context.Background(), 1, []byte(`domain="nosec.org"`))
+	if err != errCanceled {
+		t.Fatalf("expected errCanceled, got %v", err)
+	}
+}
+
+func TestSetDeadlineResetClearsExpired(t *testing.T) {
+	ff := NewFofaClient([]byte("email"), []byte("key"))
+
+	ff.SetDeadline(10 * time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	ff.SetDeadline(0)
+
+	if err := ff.expiredErr(); err != nil {
+		t.Fatalf("expected deadline to be cleared, got %v", err)
+	}
+}
+
+// TestSetDeadlineRenewBeforeExpiry exercises the standard "renew an idle
+// timeout" pattern: a second SetDeadline before the first one fires must
+// stop the first timer, not just let it fire later and clobber the
+// renewed deadline.
+func TestSetDeadlineRenewBeforeExpiry(t *testing.T) {
+	ff := NewFofaClient([]byte("email"), []byte("key"))
+
+	ff.SetDeadline(100 * time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	ff.SetDeadline(100 * time.Millisecond)
+
+	// The first timer would have fired at the 100ms mark measured from
+	// the first SetDeadline, i.e. ~50ms from here. Confirm it didn't.
+	time.Sleep(70 * time.Millisecond)
+	if err := ff.expiredErr(); err != nil {
+		t.Fatalf("renewed deadline expired early: %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if err := ff.expiredErr(); err != errCanceled {
+		t.Fatalf("expected renewed deadline to expire on its own schedule, got %v", err)
+	}
+}
+
+// TestSetDeadlineRenewalSurvivesInFlightRequest guards against renewal
+// cancelling the very requests it's supposed to leave running: a request
+// started under one deadline must not be aborted just because the caller
+// renews that deadline before it fires.
+func TestSetDeadlineRenewalSurvivesInFlightRequest(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+		fmt.Fprint(w, `{"size":1,"results":["host1.com"]}`)
+	}))
+	defer srv.Close()
+
+	ff := NewFofaClient([]byte("email"), []byte("key"))
+	ff.baseURL = srv.URL + "?"
+	ff.SetDeadline(time.Hour)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := ff.QueryAsJSONContext(context.Background(), 1, []byte(`domain="nosec.org"`))
+		done <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond) // let the request reach the server and block there
+	ff.SetDeadline(time.Hour)         // renewal must not cancel the request above
+	close(block)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("in-flight request failed after deadline renewal: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("in-flight request never completed")
+	}
+}
@@ -0,0 +1,135 @@
+package fofa
+
+import (
+	"strings"
+
+	"github.com/buger/jsonparser"
+)
+
+// Field identifies a field of a Fofa result that can be requested and
+// populated on Result.
+type Field string
+
+// The set of fields the Fofa search API currently supports.
+const (
+	FieldDomain   Field = "domain"
+	FieldHost     Field = "host"
+	FieldIP       Field = "ip"
+	FieldPort     Field = "port"
+	FieldTitle    Field = "title"
+	FieldCountry  Field = "country"
+	FieldCity     Field = "city"
+	FieldServer   Field = "server"
+	FieldBanner   Field = "banner"
+	FieldOS       Field = "os"
+	FieldASN      Field = "asn"
+	FieldCert     Field = "cert"
+	FieldProtocol Field = "protocol"
+)
+
+// defaultFields mirrors the set QueryAsJSON requests when the caller
+// doesn't specify any.
+var defaultFields = []Field{
+	FieldDomain, FieldHost, FieldIP, FieldPort, FieldTitle, FieldCountry, FieldCity,
+}
+
+// joinFields renders fields as the comma-separated list the API expects.
+func joinFields(fields []Field) []byte {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = string(f)
+	}
+	return []byte(strings.Join(names, ","))
+}
+
+// parseResults decodes the "results" array of a Fofa JSON reply into a
+// typed Results set, in place of splitting the raw bytes. When len(fields)
+// == 1 the server replies with a flat array of strings; otherwise each
+// result is itself an array of strings ordered like fields.
+func parseResults(data []byte, fields []Field) (Results, error) {
+	var (
+		parsed   Results
+		parseErr error
+	)
+
+	_, err := jsonparser.ArrayEach(data, func(value []byte, dataType jsonparser.ValueType, _ int, iterErr error) {
+		if parseErr != nil {
+			return
+		}
+		if iterErr != nil {
+			parseErr = iterErr
+			return
+		}
+
+		var r Result
+		switch dataType {
+		case jsonparser.String:
+			if len(fields) > 0 {
+				setField(&r, fields[0], unquote(value))
+			}
+		case jsonparser.Array:
+			idx := 0
+			_, err := jsonparser.ArrayEach(value, func(v []byte, _ jsonparser.ValueType, _ int, _ error) {
+				if idx < len(fields) {
+					setField(&r, fields[idx], unquote(v))
+				}
+				idx++
+			})
+			if err != nil {
+				parseErr = err
+				return
+			}
+		default:
+			parseErr = errFofaReplyWrongFormat
+			return
+		}
+		parsed = append(parsed, r)
+	}, "results")
+	if err != nil {
+		return nil, err
+	}
+	return parsed, parseErr
+}
+
+// unquote unescapes a raw JSON string value as returned by jsonparser's
+// ArrayEach callback, falling back to the raw bytes if it isn't valid
+// JSON-escaped text (e.g. a bare number serialized as a string).
+func unquote(value []byte) string {
+	s, err := jsonparser.ParseString(value)
+	if err != nil {
+		return string(value)
+	}
+	return s
+}
+
+// setField assigns v to whichever field of r corresponds to f.
+func setField(r *Result, f Field, v string) {
+	switch f {
+	case FieldDomain:
+		r.Domain = v
+	case FieldHost:
+		r.Host = v
+	case FieldIP:
+		r.IP = v
+	case FieldPort:
+		r.Port = v
+	case FieldTitle:
+		r.Title = v
+	case FieldCountry:
+		r.Country = v
+	case FieldCity:
+		r.City = v
+	case FieldServer:
+		r.Server = v
+	case FieldBanner:
+		r.Banner = v
+	case FieldOS:
+		r.OS = v
+	case FieldASN:
+		r.ASN = v
+	case FieldCert:
+		r.Cert = v
+	case FieldProtocol:
+		r.Protocol = v
+	}
+}
@@ -0,0 +1,33 @@
+package fofa
+
+import "testing"
+
+func TestParseResultsMultiField(t *testing.T) {
+	data := []byte(`{"size":2,"results":[["a.com","1.2.3.4","Title, with a comma"],["b.com","5.6.7.8","\"quoted\" title"]]}`)
+	fields := []Field{FieldDomain, FieldIP, FieldTitle}
+
+	got, err := parseResults(data, fields)
+	if err != nil {
+		t.Fatalf("parseResults: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d results, want 2", len(got))
+	}
+	if got[0].Domain != "a.com" || got[0].IP != "1.2.3.4" || got[0].Title != "Title, with a comma" {
+		t.Fatalf("unexpected first result: %+v", got[0])
+	}
+	if got[1].Domain != "b.com" || got[1].Title != `"quoted" title` {
+		t.Fatalf("unexpected second result: %+v", got[1])
+	}
+}
+
+func TestParseResultsSingleField(t *testing.T) {
+	data := []byte(`{"size":2,"results":["a.com","b.com"]}`)
+	got, err := parseResults(data, []Field{FieldDomain})
+	if err != nil {
+		t.Fatalf("parseResults: %v", err)
+	}
+	if len(got) != 2 || got[0].Domain != "a.com" || got[1].Domain != "b.com" {
+		t.Fatalf("unexpected results: %+v", got)
+	}
+}
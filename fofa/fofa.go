@@ -14,6 +14,7 @@
 package fofa
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"errors"
@@ -21,37 +22,51 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"sync"
+	"time"
 
 	"bytes"
 
 	"github.com/DivineRapier/go-tools/log"
 
-	"strings"
-
 	"github.com/buger/jsonparser"
 )
 
 // Fofa a fofa client can be used to make queries
 type Fofa struct {
-	email []byte
-	key   []byte
+	email   []byte
+	key     []byte
+	baseURL string
 	*http.Client
+
+	mu          sync.Mutex
+	deadlineCtx context.Context
+	timer       *time.Timer
+	generation  uint64
+	expired     bool
 }
 
 // Result represents a record of the query results
 // contain domain host  ip  port title country city
-type result struct {
-	Domain  string `json:"domain"`
-	Host    string `json:"host"`
-	IP      string `json:"ip"`
-	Port    string `json:"port"`
-	Title   string `json:"title"`
-	Country string `json:"country"`
-	City    string `json:"city"`
+// and, when requested, server banner os asn cert protocol
+type Result struct {
+	Domain   string `json:"domain"`
+	Host     string `json:"host"`
+	IP       string `json:"ip"`
+	Port     string `json:"port"`
+	Title    string `json:"title"`
+	Country  string `json:"country"`
+	City     string `json:"city"`
+	Server   string `json:"server"`
+	Banner   string `json:"banner"`
+	OS       string `json:"os"`
+	ASN      string `json:"asn"`
+	Cert     string `json:"cert"`
+	Protocol string `json:"protocol"`
 }
 
 // Results fofa result set
-type Results []result
+type Results []Result
 
 const (
 	defaultAPIUrl = "https://fofa.so/api/v1/search/all?"
@@ -70,8 +85,9 @@ func NewFofaClient(email, key []byte) *Fofa {
 	}
 
 	return &Fofa{
-		email: email,
-		key:   key,
+		email:   email,
+		key:     key,
+		baseURL: defaultAPIUrl,
 		Client: &http.Client{
 			Transport: transCfg, // disable tls verify
 		},
@@ -83,6 +99,15 @@ func NewFofaClient(email, key []byte) *Fofa {
 // curl "https://fofa.so/api/v1/search/all?email=${FOFA_EMAIL}&key=${FOFA_KEY}&qbase64={}"
 // host title ip domain port country city
 func (ff *Fofa) QueryAsJSON(page uint, args ...[]byte) ([]byte, error) {
+	return ff.QueryAsJSONContext(context.Background(), page, args...)
+}
+
+// QueryAsJSONContext is QueryAsJSON with a caller-supplied context. The
+// request is aborted if ctx is done or the client's deadline (see
+// SetDeadline) fires before the reply arrives; the error returned is then
+// errCanceled for the deadline, and otherwise the HTTP client's error,
+// which wraps ctx.Err().
+func (ff *Fofa) QueryAsJSONContext(ctx context.Context, page uint, args ...[]byte) ([]byte, error) {
 	var (
 		query  = []byte(nil)
 		fields = []byte("domain,host,ip,port,title,country,city")
@@ -97,7 +122,7 @@ func (ff *Fofa) QueryAsJSON(page uint, args ...[]byte) ([]byte, error) {
 	}
 
 	q = []byte(base64.StdEncoding.EncodeToString(query))
-	q = bytes.Join([][]byte{[]byte(defaultAPIUrl),
+	q = bytes.Join([][]byte{[]byte(ff.baseURL),
 		[]byte("email="), ff.email,
 		[]byte("&key="), ff.key,
 		[]byte("&qbase64="), q,
@@ -105,7 +130,25 @@ func (ff *Fofa) QueryAsJSON(page uint, args ...[]byte) ([]byte, error) {
 		[]byte("&page="), []byte(strconv.Itoa(int(page))),
 	}, []byte(""))
 	fmt.Printf("%s\n", q)
-	resp, err := ff.Get(string(q))
+
+	if err := ff.expiredErr(); err != nil {
+		log.Errorf("%v\n", err.Error())
+		return nil, err
+	}
+
+	ctx, cancel := ff.withDeadline(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, string(q), nil)
+	if err != nil {
+		log.Errorf("%v\n", err.Error())
+		return nil, err
+	}
+	resp, err := ff.Do(req)
+	if err != nil && ff.expiredErr() != nil {
+		// The client's deadline fired while the request was in flight.
+		err = errCanceled
+	}
 	if err != nil {
 		fmt.Printf("err != nil: %v\n", err != nil)
 		log.Errorf("%v\n", err.Error())
@@ -122,58 +165,35 @@ func (ff *Fofa) QueryAsJSON(page uint, args ...[]byte) ([]byte, error) {
 	return buf, err
 }
 
-// QueryAsArray make a fofa query and
-// return array data as result
-// echo 'domain="nosec.org"' | base64 - | xargs -I{}
-// curl "https://fofa.so/api/v1/search/all?email=${FOFA_EMAIL}&key=${FOFA_KEY}&qbase64={}"
-func (ff *Fofa) QueryAsArray(page uint, args ...[]byte) (Results, error) {
+// QueryAsArray make a fofa query and return a typed result set, decoded
+// from the JSON reply rather than split out of it byte by byte. fields
+// selects which fields are populated, and in what order they're requested
+// from the server; it defaults to domain, host, ip, port, title, country,
+// city when omitted.
+func (ff *Fofa) QueryAsArray(page uint, query string, fields ...Field) (Results, error) {
+	return ff.QueryAsArrayContext(context.Background(), page, query, fields...)
+}
 
-	var (
-		mapFields   = make(map[string]int)
-		resultArray = [][]byte(nil)
-	)
+// QueryAsArrayContext is QueryAsArray with a caller-supplied context, see
+// QueryAsJSONContext.
+func (ff *Fofa) QueryAsArrayContext(ctx context.Context, page uint, query string, fields ...Field) (Results, error) {
+	if len(fields) == 0 {
+		fields = defaultFields
+	}
 
-	data, err := ff.QueryAsJSON(page, args...)
+	data, err := ff.QueryAsJSONContext(ctx, page, []byte(query), joinFields(fields))
 	if err != nil {
 		log.Errorf("err: %v\n", err.Error())
 		return nil, err
 	}
 
-	// map field to index
-	if len(args) > 1 && args[1] != nil {
-		fields := strings.Split(string(args[1]), ",")
-		for i, field := range fields {
-			mapFields[strings.Trim(field, " ")] = i
-		}
-	} else {
-		mapFields["domain"] = 0
-		mapFields["host"] = 1
-		mapFields["ip"] = 2
-		mapFields["port"] = 3
-		mapFields["title"] = 4
-		mapFields["country"] = 5
-		mapFields["city"] = 6
-
-	}
-
 	errmsg, err := jsonparser.GetString(data, "errmsg")
 	// err equals to nil on error
 	if err == nil {
-		err = errors.New(errmsg)
 		log.Errorf("err: %v\n", errmsg)
 		return nil, errors.New(errmsg)
 	}
 
-	results, dataType, _, err := jsonparser.Get(data, "results")
-
-	switch {
-	case dataType != jsonparser.Array:
-		log.Errorf("err: %v\n", err.Error())
-		return nil, err
-	case err != nil:
-		log.Errorf("err: %v\n", err.Error())
-		return nil, err
-	}
 	size, err := jsonparser.GetInt(data, "size")
 	if err != nil {
 		log.Errorf("fofa reply with wrong format.\n%s\n", data)
@@ -183,36 +203,11 @@ func (ff *Fofa) QueryAsArray(page uint, args ...[]byte) (Results, error) {
 		log.Errorf("no data in fofa reply.\n%s\n", data)
 		return nil, errFofaReplyNoData
 	}
-	if len(mapFields) > 1 {
-		resultArray = bytes.Split(results[2:len(results)-2], []byte("],["))
-	} else {
-		resultArray = bytes.Split(results[1:len(results)-1], []byte{','})
-	}
-	queryArray := make(Results, len(resultArray), len(resultArray))
-	for i, v := range resultArray {
-		tmp := bytes.Split(v, []byte{','})
-
-		if a, ok := mapFields["domain"]; ok {
-			queryArray[i].Domain = string(tmp[a][1 : len(tmp[a])-1])
-		}
-		if a, ok := mapFields["host"]; ok {
-			queryArray[i].Host = string(tmp[a][1 : len(tmp[a])-1])
-		}
-		if a, ok := mapFields["ip"]; ok {
-			queryArray[i].IP = string(tmp[a][1 : len(tmp[a])-1])
-		}
-		if a, ok := mapFields["port"]; ok {
-			queryArray[i].Port = string(tmp[a][1 : len(tmp[a])-1])
-		}
-		if a, ok := mapFields["title"]; ok {
-			queryArray[i].Title = string(tmp[a][1 : len(tmp[a])-1])
-		}
-		if a, ok := mapFields["country"]; ok {
-			queryArray[i].Country = string(tmp[a][1 : len(tmp[a])-1])
-		}
-		if a, ok := mapFields["city"]; ok {
-			queryArray[i].City = string(tmp[a][1 : len(tmp[a])-1])
-		}
+
+	queryArray, err := parseResults(data, fields)
+	if err != nil {
+		log.Errorf("err: %v\n", err.Error())
+		return nil, err
 	}
 	return queryArray, nil
-}
\ No newline at end of file
+}
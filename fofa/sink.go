@@ -0,0 +1,216 @@
+package fofa
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Sink receives query results one at a time, e.g. to export them to a
+// file or forward them to a downstream service.
+type Sink interface {
+	Write(r Result) error
+	Close() error
+}
+
+// QueryToSink streams query across up to pages pages (0 means until the
+// server runs out of data) and writes every hit to sink as it arrives,
+// without buffering the full result set in memory. sink is not closed by
+// QueryToSink; the caller owns its lifetime.
+func (ff *Fofa) QueryToSink(ctx context.Context, query string, fields []Field, pages uint, sink Sink) error {
+	// Cancelling on return stops the stream if a sink error ends the loop
+	// before the results channel is drained.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results, errs := ff.Stream(ctx, query, fields, StreamOptions{MaxPages: pages})
+
+	for r := range results {
+		if err := sink.Write(r); err != nil {
+			return err
+		}
+	}
+	return <-errs
+}
+
+// sinkColumns is the fixed column order used by CSVSink and the field
+// order of the struct itself.
+var sinkColumns = []string{
+	"domain", "host", "ip", "port", "title", "country", "city",
+	"server", "banner", "os", "asn", "cert", "protocol",
+}
+
+func sinkRow(r Result) []string {
+	return []string{
+		r.Domain, r.Host, r.IP, r.Port, r.Title, r.Country, r.City,
+		r.Server, r.Banner, r.OS, r.ASN, r.Cert, r.Protocol,
+	}
+}
+
+// CSVSink writes results as CSV, emitting a header row before the first
+// record.
+type CSVSink struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+// NewCSVSink creates a CSVSink writing to w.
+func NewCSVSink(w io.Writer) *CSVSink {
+	return &CSVSink{w: csv.NewWriter(w)}
+}
+
+// Write implements Sink.
+func (s *CSVSink) Write(r Result) error {
+	if !s.wroteHeader {
+		if err := s.w.Write(sinkColumns); err != nil {
+			return err
+		}
+		s.wroteHeader = true
+	}
+	return s.w.Write(sinkRow(r))
+}
+
+// Close implements Sink, flushing any buffered rows.
+func (s *CSVSink) Close() error {
+	s.w.Flush()
+	return s.w.Error()
+}
+
+// JSONLSink writes results as newline-delimited JSON (one object per line).
+type JSONLSink struct {
+	enc *json.Encoder
+}
+
+// NewJSONLSink creates a JSONLSink writing to w.
+func NewJSONLSink(w io.Writer) *JSONLSink {
+	return &JSONLSink{enc: json.NewEncoder(w)}
+}
+
+// Write implements Sink.
+func (s *JSONLSink) Write(r Result) error {
+	return s.enc.Encode(r)
+}
+
+// Close implements Sink. JSONLSink holds no resources of its own.
+func (s *JSONLSink) Close() error {
+	return nil
+}
+
+// NDJSONHTTPSink POSTs each result as a JSON document to a webhook URL,
+// one request per record.
+type NDJSONHTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewNDJSONHTTPSink creates a sink that POSTs each result to url as
+// application/json. A nil client uses http.DefaultClient.
+func NewNDJSONHTTPSink(url string, client *http.Client) *NDJSONHTTPSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &NDJSONHTTPSink{url: url, client: client}
+}
+
+// Write implements Sink.
+func (s *NDJSONHTTPSink) Write(r Result) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("fofa: webhook %s replied with status %s", s.url, resp.Status)
+	}
+	return nil
+}
+
+// Close implements Sink. NDJSONHTTPSink holds no resources of its own.
+func (s *NDJSONHTTPSink) Close() error {
+	return nil
+}
+
+// ElasticBulkSink batches results and submits them to an Elasticsearch
+// _bulk endpoint, flushing every BatchSize records and on Close.
+type ElasticBulkSink struct {
+	// URL is the Elasticsearch base URL, e.g. "http://localhost:9200".
+	URL string
+	// Index is the target index name.
+	Index string
+	// BatchSize is how many records are buffered before a flush; it
+	// defaults to 500.
+	BatchSize int
+	Client    *http.Client
+
+	buf []Result
+}
+
+// Write implements Sink, flushing once BatchSize records have buffered.
+func (s *ElasticBulkSink) Write(r Result) error {
+	s.buf = append(s.buf, r)
+	if len(s.buf) >= s.batchSize() {
+		return s.flush()
+	}
+	return nil
+}
+
+// Close implements Sink, flushing any remaining buffered records.
+func (s *ElasticBulkSink) Close() error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+	return s.flush()
+}
+
+func (s *ElasticBulkSink) batchSize() int {
+	if s.BatchSize <= 0 {
+		return 500
+	}
+	return s.BatchSize
+}
+
+func (s *ElasticBulkSink) client() *http.Client {
+	if s.Client == nil {
+		return http.DefaultClient
+	}
+	return s.Client
+}
+
+func (s *ElasticBulkSink) flush() error {
+	var body bytes.Buffer
+	for _, r := range s.buf {
+		action, err := json.Marshal(map[string]interface{}{
+			"index": map[string]string{"_index": s.Index},
+		})
+		if err != nil {
+			return err
+		}
+		doc, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(doc)
+		body.WriteByte('\n')
+	}
+
+	resp, err := s.client().Post(s.URL+"/_bulk", "application/x-ndjson", &body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("fofa: elasticsearch bulk request to %s replied with status %s", s.URL, resp.Status)
+	}
+	s.buf = s.buf[:0]
+	return nil
+}
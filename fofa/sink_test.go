@@ -0,0 +1,94 @@
+package fofa
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCSVSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewCSVSink(&buf)
+
+	if err := sink.Write(Result{Domain: "a.com", Host: "a.com:80", Title: "hi, there"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, strings.Join(sinkColumns, ",")+"\n") {
+		t.Fatalf("missing header, got %q", out)
+	}
+	if !strings.Contains(out, `a.com,a.com:80,,,"hi, there"`) {
+		t.Fatalf("missing expected row, got %q", out)
+	}
+}
+
+func TestJSONLSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONLSink(&buf)
+
+	if err := sink.Write(Result{Domain: "a.com"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := sink.Write(Result{Domain: "b.com"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	var r Result
+	if err := json.Unmarshal([]byte(lines[0]), &r); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if r.Domain != "a.com" {
+		t.Fatalf("got domain %q, want a.com", r.Domain)
+	}
+}
+
+func TestNDJSONHTTPSink(t *testing.T) {
+	var received Result
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	sink := NewNDJSONHTTPSink(srv.URL, nil)
+	if err := sink.Write(Result{Domain: "a.com"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if received.Domain != "a.com" {
+		t.Fatalf("server received domain %q, want a.com", received.Domain)
+	}
+}
+
+func TestElasticBulkSinkFlushesOnClose(t *testing.T) {
+	var body bytes.Buffer
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body.ReadFrom(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	sink := &ElasticBulkSink{URL: srv.URL, Index: "fofa", BatchSize: 10}
+	if err := sink.Write(Result{Domain: "a.com"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if body.Len() != 0 {
+		t.Fatal("expected no request before Close/batch full")
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !strings.Contains(body.String(), `"_index":"fofa"`) {
+		t.Fatalf("unexpected bulk body: %q", body.String())
+	}
+}
@@ -0,0 +1,244 @@
+package fofa
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how Stream retries a page fetch that failed with a
+// transient error.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a page is fetched before giving
+	// up. 0 (or 1) means no retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry; it doubles on every
+	// subsequent attempt, and each delay is jittered to between half and
+	// all of its value. Defaults to 500ms.
+	BaseDelay time.Duration
+	// MaxDelay caps the computed backoff. Defaults to 30s.
+	MaxDelay time.Duration
+}
+
+// RateLimit paces the requests Stream issues.
+type RateLimit struct {
+	// RPS is the sustained requests/sec allowed; 0 disables rate limiting.
+	RPS float64
+	// Burst is the number of requests that may run back-to-back before
+	// RPS pacing kicks in.
+	Burst int
+}
+
+// StreamOptions configures Stream.
+type StreamOptions struct {
+	// MaxPages bounds how many pages Stream walks; 0 walks until the
+	// server returns an empty page.
+	MaxPages uint
+	// PageSize documents the number of hits the Fofa account returns per
+	// page; it isn't sent on the wire (the API doesn't accept it) but
+	// callers can use it to estimate MaxPages from an expected hit count.
+	PageSize uint
+	// Concurrency is how many pages are fetched in parallel. 1 means
+	// pages are fetched one at a time.
+	Concurrency int
+	RetryPolicy RetryPolicy
+	RateLimit   RateLimit
+}
+
+// Stream walks query/fields page by page per opts, sending every hit on
+// the returned channel and closing it once the server runs out of pages,
+// opts.MaxPages is reached, ctx is done, or a page fetch fails with a
+// non-retryable error or with a retryable one that outlasts the retries.
+// At most one error is ever sent on the error channel. Stream returns
+// immediately; both channels must be drained by the caller.
+func (ff *Fofa) Stream(ctx context.Context, query string, fields []Field, opts StreamOptions) (<-chan Result, <-chan error) {
+	results := make(chan Result)
+	errs := make(chan error, 1)
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var limiter *tokenBucket
+	if opts.RateLimit.RPS > 0 {
+		limiter = newTokenBucket(opts.RateLimit.RPS, opts.RateLimit.Burst)
+	}
+
+	go func() {
+		defer close(results)
+		defer close(errs)
+
+		var (
+			pageMu  sync.Mutex
+			next    uint = 1
+			stopped bool
+			wg      sync.WaitGroup
+		)
+		stop := func() { pageMu.Lock(); stopped = true; pageMu.Unlock() }
+		reportErr := func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}
+
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					pageMu.Lock()
+					if stopped || (opts.MaxPages != 0 && next > opts.MaxPages) {
+						pageMu.Unlock()
+						return
+					}
+					page := next
+					next++
+					pageMu.Unlock()
+
+					if limiter != nil {
+						if err := limiter.wait(ctx); err != nil {
+							reportErr(err)
+							stop()
+							return
+						}
+					}
+
+					items, err := ff.fetchPageWithRetry(ctx, page, query, fields, opts.RetryPolicy)
+					if err != nil {
+						if err != errFofaReplyNoData {
+							reportErr(err)
+						}
+						stop()
+						return
+					}
+					if len(items) == 0 {
+						stop()
+						return
+					}
+					for _, r := range items {
+						select {
+						case results <- r:
+						case <-ctx.Done():
+							stop()
+							return
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+
+	return results, errs
+}
+
+// fetchPageWithRetry fetches a single page, retrying retryable errors per
+// policy with exponential backoff and jitter.
+func (ff *Fofa) fetchPageWithRetry(ctx context.Context, page uint, query string, fields []Field, policy RetryPolicy) (Results, error) {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	var (
+		items Results
+		err   error
+	)
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		items, err = ff.QueryAsArrayContext(ctx, page, query, fields...)
+		if err == nil || !isRetryable(err) {
+			return items, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoffDelay(attempt, policy)):
+		}
+	}
+	return items, err
+}
+
+// isRetryable reports whether err is a transient failure worth retrying:
+// a network-level timeout/temporary error, or the Fofa "820000 too fast"
+// rate-limit reply.
+func isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if strings.Contains(err.Error(), "820000") || strings.Contains(err.Error(), "too fast") {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout() || netErr.Temporary()
+	}
+	return false
+}
+
+// backoffDelay computes an exponential backoff with jitter for the given
+// (zero-based) attempt number.
+func backoffDelay(attempt int, policy RetryPolicy) time.Duration {
+	base := policy.BaseDelay
+	if base <= 0 {
+		base = 500 * time.Millisecond
+	}
+	max := policy.MaxDelay
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+
+	delay := base << uint(attempt)
+	if delay <= 0 || delay > max {
+		delay = max
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+// tokenBucket is a minimal token-bucket rate limiter, just enough to pace
+// Stream's page requests without pulling in an extra dependency.
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	max    float64
+	rps    float64
+	last   time.Time
+}
+
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &tokenBucket{tokens: float64(burst), max: float64(burst), rps: rps, last: time.Now()}
+}
+
+// wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rps
+		if b.tokens > b.max {
+			b.tokens = b.max
+		}
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) / b.rps * float64(time.Second))
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
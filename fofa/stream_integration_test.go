@@ -0,0 +1,145 @@
+package fofa
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newStreamTestClient returns a Fofa client wired up to talk to srv instead
+// of fofa.so, for exercising Stream end-to-end.
+func newStreamTestClient(srv *httptest.Server) *Fofa {
+	ff := NewFofaClient([]byte("email"), []byte("key"))
+	ff.baseURL = srv.URL + "?"
+	return ff
+}
+
+func collectStream(t *testing.T, results <-chan Result, errs <-chan error) (Results, error) {
+	t.Helper()
+	var got Results
+	for r := range results {
+		got = append(got, r)
+	}
+	return got, <-errs
+}
+
+func TestStreamWalksPagesUntilEmpty(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		switch page {
+		case 1:
+			fmt.Fprint(w, `{"size":1,"results":["host1.com"]}`)
+		case 2:
+			fmt.Fprint(w, `{"size":1,"results":["host2.com"]}`)
+		default:
+			fmt.Fprint(w, `{"size":0,"results":[]}`)
+		}
+	}))
+	defer srv.Close()
+
+	ff := newStreamTestClient(srv)
+	results, errs := ff.Stream(context.Background(), `domain="nosec.org"`, []Field{FieldDomain}, StreamOptions{})
+
+	got, err := collectStream(t, results, errs)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d results, want 2: %+v", len(got), got)
+	}
+	hosts := map[string]bool{got[0].Domain: true, got[1].Domain: true}
+	if !hosts["host1.com"] || !hosts["host2.com"] {
+		t.Fatalf("unexpected results: %+v", got)
+	}
+}
+
+func TestStreamStopsAtMaxPages(t *testing.T) {
+	var requested []int
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		mu.Lock()
+		requested = append(requested, page)
+		mu.Unlock()
+		fmt.Fprintf(w, `{"size":1,"results":["host%d.com"]}`, page)
+	}))
+	defer srv.Close()
+
+	ff := newStreamTestClient(srv)
+	results, errs := ff.Stream(context.Background(), `domain="nosec.org"`, []Field{FieldDomain}, StreamOptions{MaxPages: 1})
+
+	got, err := collectStream(t, results, errs)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d results, want 1: %+v", len(got), got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requested) != 1 {
+		t.Fatalf("server saw %d requests, want 1: %v", len(requested), requested)
+	}
+}
+
+func TestStreamRetriesTransientError(t *testing.T) {
+	var attempts int
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		first := attempts == 1
+		mu.Unlock()
+
+		if first {
+			fmt.Fprint(w, `{"errmsg":"820000 too fast"}`)
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 1 {
+			fmt.Fprint(w, `{"size":1,"results":["host1.com"]}`)
+			return
+		}
+		fmt.Fprint(w, `{"size":0,"results":[]}`)
+	}))
+	defer srv.Close()
+
+	ff := newStreamTestClient(srv)
+	opts := StreamOptions{RetryPolicy: RetryPolicy{MaxAttempts: 2, BaseDelay: 5 * time.Millisecond, MaxDelay: 20 * time.Millisecond}}
+	results, errs := ff.Stream(context.Background(), `domain="nosec.org"`, []Field{FieldDomain}, opts)
+
+	got, err := collectStream(t, results, errs)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if len(got) != 1 || got[0].Domain != "host1.com" {
+		t.Fatalf("unexpected results after retry: %+v", got)
+	}
+}
+
+func TestStreamRespectsContextCancellation(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+		fmt.Fprint(w, `{"size":1,"results":["host1.com"]}`)
+	}))
+	defer srv.Close()
+	defer close(block)
+
+	ff := newStreamTestClient(srv)
+	ctx, cancel := context.WithCancel(context.Background())
+	results, errs := ff.Stream(ctx, `domain="nosec.org"`, []Field{FieldDomain}, StreamOptions{})
+
+	cancel()
+
+	got, err := collectStream(t, results, errs)
+	if err == nil {
+		t.Fatalf("expected an error from Stream after ctx cancellation, got results %+v", got)
+	}
+}
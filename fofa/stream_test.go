@@ -0,0 +1,63 @@
+package fofa
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("errmsg: 820000 too fast"), true},
+		{errors.New("request too fast, slow down"), true},
+		{errFofaReplyNoData, false},
+	}
+	for _, c := range cases {
+		if got := isRetryable(c.err); got != c.want {
+			t.Errorf("isRetryable(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestBackoffDelayRespectsMaxDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	for attempt := 0; attempt < 10; attempt++ {
+		if d := backoffDelay(attempt, policy); d > policy.MaxDelay {
+			t.Fatalf("backoffDelay(%d) = %v, want <= %v", attempt, d, policy.MaxDelay)
+		}
+	}
+}
+
+func TestTokenBucketPaces(t *testing.T) {
+	b := newTokenBucket(100, 1)
+	ctx := context.Background()
+
+	if err := b.wait(ctx); err != nil {
+		t.Fatalf("first wait: %v", err)
+	}
+	start := time.Now()
+	if err := b.wait(ctx); err != nil {
+		t.Fatalf("second wait: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+		t.Fatalf("expected second wait to be paced, took %v", elapsed)
+	}
+}
+
+func TestTokenBucketRespectsContext(t *testing.T) {
+	b := newTokenBucket(1, 1)
+	if err := b.wait(context.Background()); err != nil {
+		t.Fatalf("first wait: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.wait(ctx); err == nil {
+		t.Fatal("expected wait to return an error for a canceled context")
+	}
+}